@@ -16,14 +16,32 @@ package sql
 
 import (
 	"fmt"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"unsafe"
 
 	"github.com/pkg/errors"
 	"golang.org/x/net/context"
 
+	"github.com/cockroachdb/cockroach/pkg/settings"
 	"github.com/cockroachdb/cockroach/pkg/sql/parser"
 	"github.com/cockroachdb/cockroach/pkg/sql/sqlbase"
 	"github.com/cockroachdb/cockroach/pkg/util"
+	"github.com/cockroachdb/cockroach/pkg/util/humanizeutil"
+	"github.com/cockroachdb/cockroach/pkg/util/log"
+)
+
+// joinHashSpillThreshold bounds how much memory a hash join's build side
+// (buckets.rowContainer) may use before it spills the rest of itself to a
+// disk-backed row container; see the buckets struct and buckets.AddRow.
+var joinHashSpillThreshold = settings.RegisterByteSizeSetting(
+	"sql.hash_join.memory_spill_threshold",
+	"maximum amount of memory a hash join's build side may use before spilling to disk",
+	64<<20,
 )
 
 type joinType int
@@ -33,41 +51,359 @@ const (
 	joinTypeLeftOuter
 	joinTypeRightOuter
 	joinTypeFullOuter
+	joinTypeLeftSemi
+	joinTypeLeftAnti
+	joinTypeRightSemi
+	joinTypeRightAnti
+
+	// joinTypeLeftAntiNullAware is a LEFT ANTI JOIN variant used by the
+	// planner when rewriting `NOT IN (subquery)` / `<> ALL (subquery)`
+	// predicates, where SQL's three-valued NULL semantics require that a
+	// NULL anywhere in the join key -- on either side -- can suppress an
+	// outer row even without an exact equality match. See the NAAJ handling
+	// in hashJoinStart and joinNode.naajPartialMatch for the full rules.
+	joinTypeLeftAntiNullAware
+
+	// joinTypeAsofInner and joinTypeAsofLeftOuter implement `ASOF JOIN ...
+	// ON <equality prefix> AND a.asofCol >= b.asofCol` (or <=, <, >): rather
+	// than matching every bucket row that agrees on the equality prefix,
+	// they match the single bucket row closest to the probe row's asof
+	// value. See joinNode.asof and hashJoinStart's bucket-sorting step.
+	joinTypeAsofInner
+	joinTypeAsofLeftOuter
+)
+
+// isSemiOrAntiJoin returns true if the join only ever emits columns from one
+// side (the "outer" side) of the join, which is the case for SEMI and ANTI
+// joins.
+func (t joinType) isSemiOrAntiJoin() bool {
+	switch t {
+	case joinTypeLeftSemi, joinTypeLeftAnti, joinTypeRightSemi, joinTypeRightAnti,
+		joinTypeLeftAntiNullAware:
+		return true
+	}
+	return false
+}
+
+// isRightSemiOrAntiJoin returns true if the outer side of the join (the side
+// whose columns are exposed in the output) is the right side.
+func (t joinType) isRightSemiOrAntiJoin() bool {
+	return t == joinTypeRightSemi || t == joinTypeRightAnti
+}
+
+// isAsofJoin returns true for the ASOF JOIN variants, which match at most
+// one bucket row per probe row (the closest one by the asof column) instead
+// of every bucket row agreeing on the equality prefix.
+func (t joinType) isAsofJoin() bool {
+	return t == joinTypeAsofInner || t == joinTypeAsofLeftOuter
+}
+
+// hasAtMostOneMatchPerProbeRow reports whether every probe row can produce
+// at most one output row, which is true of ASOF JOIN by construction. The
+// DistSQL physical planner consults this (alongside the equivalent check it
+// already does for equality joins known to be keyed) to decide whether a
+// join's output row count can be bounded by its probe side's row count, e.g.
+// when sizing result buffers or choosing a parallelization strategy.
+func (n *joinNode) hasAtMostOneMatchPerProbeRow() bool {
+	return n.joinType.isAsofJoin()
+}
+
+// asofCompareOp identifies the inequality comparator of an ASOF JOIN's
+// `ON ... a.asofCol <op> b.asofCol` clause.
+type asofCompareOp int
+
+const (
+	asofGE asofCompareOp = iota
+	asofLE
+	asofGT
+	asofLT
 )
 
+// asofInfo holds the extra join-key information used by ASOF JOIN: the
+// result columns of makeJoin's usual ON-predicate machinery already capture
+// any equality prefix (`ON a.sym = b.sym AND ...`), so all that's left to
+// track here is which column on each side is being compared with which
+// operator to find the single closest match.
+type asofInfo struct {
+	leftCol, rightCol int
+	op                asofCompareOp
+}
+
+// joinAlgorithm identifies a join execution strategy that a query hint can
+// pin, overriding the planner's own heuristics (e.g. mergeJoinOrdering).
+type joinAlgorithm int
+
+const (
+	// joinAlgorithmAuto means no hint names this join; the planner picks as
+	// it always has.
+	joinAlgorithmAuto joinAlgorithm = iota
+	joinAlgorithmHash
+	joinAlgorithmMerge
+	joinAlgorithmLookup
+	// joinAlgorithmNoHash is only ever used transiently while parsing a
+	// NO_HASH_JOIN hint; it never ends up stored in forcedAlgorithm (see
+	// applyJoinHints), which instead sets the noHashJoin bool.
+	joinAlgorithmNoHash
+)
+
+func (a joinAlgorithm) String() string {
+	switch a {
+	case joinAlgorithmHash:
+		return "hash"
+	case joinAlgorithmMerge:
+		return "merge"
+	case joinAlgorithmLookup:
+		return "lookup"
+	case joinAlgorithmNoHash:
+		return "no_hash"
+	default:
+		return "auto"
+	}
+}
+
+// joinHint is a single query hint of the form `/*+ HASH_JOIN(t1, t2) */`,
+// naming the tables it applies to and the algorithm it pins (or, for
+// NO_HASH_JOIN, forbids).
+type joinHint struct {
+	algorithm joinAlgorithm
+	tables    map[string]bool
+}
+
+// joinHintBlockPattern matches an entire `/*+ ... */` optimizer-hint
+// comment. A single block can carry more than one hint (e.g.
+// `/*+ HASH_JOIN(t1, t2) MERGE_JOIN(t3, t4) */`), so this only isolates the
+// block itself; joinHintPattern then tokenizes the individual hints out of
+// its contents.
+var joinHintBlockPattern = regexp.MustCompile(`(?is)/\*\+(.*?)\*/`)
+
+// joinHintPattern matches a single `HINT_NAME(arg, arg, ...)` hint inside a
+// /*+ ... */ block. It deliberately doesn't try to validate the hint names
+// it captures -- parseJoinHints does that -- so that one malformed or
+// unrecognized hint doesn't prevent the rest of the block from being
+// parsed.
+var joinHintPattern = regexp.MustCompile(`(?i)([A-Za-z_]+)\s*\(([^)]*)\)`)
+
+// parseJoinHints extracts HASH_JOIN/MERGE_JOIN/NO_HASH_JOIN-style hints from
+// a SELECT statement's hint comment. Unrecognized hint names are silently
+// ignored, matching how most SQL engines treat optimizer hints they don't
+// understand (as advisory, not an error).
+func parseJoinHints(comment string) []joinHint {
+	var hints []joinHint
+	for _, block := range joinHintBlockPattern.FindAllStringSubmatch(comment, -1) {
+		for _, m := range joinHintPattern.FindAllStringSubmatch(block[1], -1) {
+			var algo joinAlgorithm
+			switch strings.ToUpper(m[1]) {
+			case "HASH_JOIN":
+				algo = joinAlgorithmHash
+			case "MERGE_JOIN":
+				algo = joinAlgorithmMerge
+			case "LOOKUP_JOIN":
+				algo = joinAlgorithmLookup
+			case "NO_HASH_JOIN":
+				algo = joinAlgorithmNoHash
+			default:
+				continue
+			}
+			tables := make(map[string]bool)
+			for _, t := range strings.Split(m[2], ",") {
+				if t = strings.TrimSpace(t); t != "" {
+					tables[t] = true
+				}
+			}
+			if len(tables) == 0 {
+				continue
+			}
+			hints = append(hints, joinHint{algorithm: algo, tables: tables})
+		}
+	}
+	return hints
+}
+
+// sourceTableNames collects the table aliases visible in a data source, for
+// matching against a join hint's argument list.
+func sourceTableNames(info *dataSourceInfo) map[string]bool {
+	names := make(map[string]bool, len(info.sourceAliases))
+	for _, alias := range info.sourceAliases {
+		if t := alias.name.Table(); t != "" {
+			names[t] = true
+		}
+	}
+	return names
+}
+
+func hintMatchesEither(h joinHint, leftTables, rightTables map[string]bool) bool {
+	for t := range h.tables {
+		if leftTables[t] || rightTables[t] {
+			return true
+		}
+	}
+	return false
+}
+
+// applyJoinHints resolves which, if any, of the SELECT statement's query
+// hints name this join's two input tables and records the forced or
+// forbidden algorithm on the node. makeJoin calls it once both sides' table
+// aliases are known, passing whatever hint comment (if any) the caller
+// found attached to this JOIN; hint-free call sites just pass "", for which
+// parseJoinHints returns no hints and this is a no-op.
+func (n *joinNode) applyJoinHints(hints []joinHint) {
+	leftTables := sourceTableNames(n.left.info)
+	rightTables := sourceTableNames(n.right.info)
+	for _, h := range hints {
+		if !hintMatchesEither(h, leftTables, rightTables) {
+			continue
+		}
+		if h.algorithm == joinAlgorithmNoHash {
+			n.noHashJoin = true
+			continue
+		}
+		n.forcedAlgorithm = h.algorithm
+	}
+}
+
+// chooseAlgorithm resolves forcedAlgorithm/noHashJoin against what expandPlan
+// actually made feasible (mergeJoinOrdering is only non-empty once a usable
+// ordering exists), falling back with a warning rather than failing the
+// query outright when a hint can't be honored. It runs in Start, after
+// expandPlan has finished and before probing begins.
+//
+// DistSQL physical planning reads the resolved forcedAlgorithm/noHashJoin
+// via distsqlForcedAlgorithm to choose between a hash, merge, or lookup join
+// processor; hashJoinStart/Next (this package's own local execution engine)
+// always runs a hash join regardless, since that's the only strategy
+// implemented locally.
+func (n *joinNode) chooseAlgorithm(ctx context.Context) {
+	if n.forcedAlgorithm == joinAlgorithmMerge && len(n.mergeJoinOrdering) == 0 {
+		log.Warningf(ctx, "join hint MERGE_JOIN is infeasible (no usable ordering on the "+
+			"equality columns); falling back to the default join algorithm")
+		n.forcedAlgorithm = joinAlgorithmAuto
+	}
+	if n.noHashJoin && n.forcedAlgorithm == joinAlgorithmAuto && len(n.mergeJoinOrdering) == 0 {
+		log.Warningf(ctx, "join hint NO_HASH_JOIN is infeasible (no usable ordering for a merge "+
+			"join, and no lookup join is available); falling back to a hash join")
+		n.noHashJoin = false
+	}
+}
+
+// distsqlForcedAlgorithm exposes the resolved hint state to the DistSQL
+// physical planner (outside this package snapshot), which is what actually
+// dispatches between join processor implementations.
+func (n *joinNode) distsqlForcedAlgorithm() (algorithm joinAlgorithm, noHash bool) {
+	return n.forcedAlgorithm, n.noHashJoin
+}
+
 // bucket here is the set of rows for a given group key (comprised of
 // columns specified by the join constraints), 'seen' is used to determine if
-// there was a matching row in the opposite stream.
+// there was a matching row in the opposite stream. seen is a bitmap rather
+// than a []bool so that MarkSeen is safe to call concurrently from the
+// parallel probe workers started by Next (see joinNode.beginParallelProbe):
+// every worker may be marking bits in the same bucket's bitmap at once, and
+// the set-one-bit operation a probe performs never needs to observe any
+// other worker's write, only make its own visible.
 type bucket struct {
-	rows []parser.Datums
-	seen []bool
+	// rowIdxs holds, not the rows themselves, but each row's index into the
+	// owning buckets.rowContainer -- the actual storage, which may have
+	// spilled some or all of these rows to disk. Looking a row up by index
+	// (buckets.Row) goes through rowContainer every time rather than
+	// through a second in-memory copy kept here, so a bucket's footprint
+	// stays a handful of ints per row regardless of whether rowContainer is
+	// spilled.
+	rowIdxs []int
+	seen    []uint64
 }
 
 func (b *bucket) Seen(i int) bool {
-	return b.seen[i]
+	return atomic.LoadUint64(&b.seen[i/64])&(1<<uint(i%64)) != 0
 }
 
-func (b *bucket) Rows() []parser.Datums {
-	return b.rows
+// NumRows reports how many rows this bucket holds.
+func (b *bucket) NumRows() int {
+	return len(b.rowIdxs)
 }
 
 func (b *bucket) MarkSeen(i int) {
-	b.seen[i] = true
+	word, bit := i/64, uint64(1)<<uint(i%64)
+	for {
+		old := atomic.LoadUint64(&b.seen[word])
+		if old&bit != 0 {
+			return
+		}
+		if atomic.CompareAndSwapUint64(&b.seen[word], old, old|bit) {
+			return
+		}
+	}
 }
 
-func (b *bucket) AddRow(row parser.Datums) {
-	b.rows = append(b.rows, row)
+func (b *bucket) AddRow(rowIdx int) {
+	b.rowIdxs = append(b.rowIdxs, rowIdx)
 }
 
 type buckets struct {
-	buckets      map[string]*bucket
-	rowContainer *sqlbase.RowContainer
+	buckets map[string]*bucket
+
+	// rowContainer is the actual storage for every right-side row added via
+	// AddRow. It starts out purely in-memory; the first time growing it
+	// trips its own bound account's budget, AddRow calls its SpillToDisk
+	// method, which moves every row already buffered (and everything added
+	// from then on) out to temp storage. Buckets themselves only ever keep
+	// the row's index into rowContainer (see bucket.rowIdxs); every read --
+	// Row, probing, emitting unmatched rows, sorting an ASOF bucket -- goes
+	// back through rowContainer.GetRow, so a bucket's own memory footprint
+	// never grows past a handful of ints per row regardless of whether
+	// rowContainer is spilled.
+	rowContainer *sqlbase.DiskBackedRowContainer
+
+	// The fields below are only populated when building for a
+	// joinTypeLeftAntiNullAware join; see hashJoinStart and
+	// joinNode.naajPartialMatch.
+
+	// rightHasNull is set if any right-side row had a NULL anywhere in its
+	// equality key. Per NOT IN semantics, this alone is enough to suppress
+	// every row of the anti join.
+	rightHasNull bool
+	// rightRowCount is the total number of rows seen on the right; a fully
+	// NULL left key is suppressed as soon as this is non-zero.
+	rightRowCount int
+	// partialKeyIndex maps a bitmask over the equality columns (identifying
+	// which of them are "present") to the set of encodings of right rows'
+	// values at exactly those columns, for rows that are non-NULL in all of
+	// them. It lets naajPartialMatch answer "does some right row agree with
+	// me on every column where I'm non-NULL?" for a left row that itself
+	// contains a NULL.
+	partialKeyIndex map[uint64]map[string]bool
+
+	// spill-to-disk bookkeeping; see AddRow.
+
+	// spilled is set once rowContainer.SpillToDisk has been called, so AddRow
+	// knows not to call it again.
+	spilled bool
+	// spillRowCount counts the rows AddRow added from the spill onward (the
+	// row that triggered it included); rows already buffered when
+	// rowContainer spilled are not counted. spillByteCount adds
+	// sqlbase.SizeOfDatums per counted row. Both feed the
+	// hash_join.spill_count/spill_bytes metrics.
+	spillByteCount int64
+	spillRowCount  int64
+	// maxBucketRows is the row count of the largest bucket built so far,
+	// used to detect the "grace hash" case where a single equality key has
+	// so many rows that spilling the rest of the build side isn't enough.
+	maxBucketRows int
 }
 
 func (b *buckets) Buckets() map[string]*bucket {
 	return b.buckets
 }
 
+// SpillStats reports spillRowCount and spillByteCount: the rows of this hash
+// join's build side added after it spilled to disk, and SizeOfDatums per such
+// row. Those rows are still charged to bucketsMemAcc as well. It's
+// read by the executor's per-query metrics (sql.hash_join.spill_count/
+// sql.hash_join.spill_bytes counters) outside this file; zero values mean
+// the join never spilled.
+func (b *buckets) SpillStats() (rowCount, byteCount int64) {
+	return b.spillRowCount, b.spillByteCount
+}
+
 func (b *buckets) AddRow(
 	ctx context.Context, acc WrappedMemoryAccount, encoding []byte, row parser.Datums,
 ) error {
@@ -76,14 +412,37 @@ func (b *buckets) AddRow(
 		bk = &bucket{}
 	}
 
-	rowCopy, err := b.rowContainer.AddRow(ctx, row)
+	rowIdx := b.rowContainer.NumRows()
+	err := b.rowContainer.AddRow(ctx, row)
 	if err != nil {
-		return err
+		if !isMemoryBudgetExceededErr(err) || b.spilled {
+			return err
+		}
+		// The in-memory row storage is full; spill everything buffered so
+		// far (and this row, and everything after it) to disk rather than
+		// failing the query outright. This is rowContainer's own budget
+		// being exhausted, not bucketsMemAcc's — the per-row overhead
+		// tracked below is a few bytes per row and was never going to be
+		// what runs a large build side out of memory.
+		if err := b.rowContainer.SpillToDisk(ctx); err != nil {
+			return err
+		}
+		b.spilled = true
+		log.Warningf(ctx, "hash join build side exceeded %s; spilled to disk",
+			humanizeutil.IBytes(joinHashSpillThreshold.Get()))
+		rowIdx = b.rowContainer.NumRows()
+		if err = b.rowContainer.AddRow(ctx, row); err != nil {
+			return err
+		}
+	}
+	if b.spilled {
+		b.spillRowCount++
+		b.spillByteCount += int64(sqlbase.SizeOfDatums)
 	}
 	if err := acc.Grow(ctx, sqlbase.SizeOfDatums); err != nil {
 		return err
 	}
-	bk.AddRow(rowCopy)
+	bk.AddRow(rowIdx)
 
 	if !ok {
 		b.buckets[string(encoding)] = bk
@@ -91,19 +450,31 @@ func (b *buckets) AddRow(
 	return nil
 }
 
-const sizeOfBoolSlice = unsafe.Sizeof([]bool{})
-const sizeOfBool = unsafe.Sizeof(true)
+// Row fetches bucket row i (an index into a bucket's rowIdxs, not into
+// rowContainer directly) back out of rowContainer. It's the only way
+// anything outside this type reads a right-side row's contents: probing,
+// emitting unmatched rows, and sorting an ASOF bucket all go through this
+// rather than keeping their own copy, so memory use tracks whatever
+// rowContainer itself is doing (in-memory or spilled) instead of a second,
+// always-in-memory copy.
+func (b *buckets) Row(ctx context.Context, bk *bucket, i int) (parser.Datums, error) {
+	return b.rowContainer.GetRow(ctx, bk.rowIdxs[i])
+}
+
+const sizeOfUint64Slice = unsafe.Sizeof([]uint64{})
+const sizeOfUint64 = unsafe.Sizeof(uint64(0))
 
-// InitSeen initializes the seen array for each of the buckets. It must be run
-// before the buckets' seen state is used.
+// InitSeen initializes the seen bitmap for each of the buckets. It must be
+// run before the buckets' seen state is used.
 func (b *buckets) InitSeen(ctx context.Context, acc WrappedMemoryAccount) error {
 	for _, bucket := range b.buckets {
+		numWords := (bucket.NumRows() + 63) / 64
 		if err := acc.Grow(
-			ctx, int64(sizeOfBoolSlice+uintptr(len(bucket.rows))*sizeOfBool),
+			ctx, int64(sizeOfUint64Slice+uintptr(numWords)*sizeOfUint64),
 		); err != nil {
 			return err
 		}
-		bucket.seen = make([]bool, len(bucket.rows))
+		bucket.seen = make([]uint64, numWords)
 	}
 	return nil
 }
@@ -112,6 +483,7 @@ func (b *buckets) Close(ctx context.Context) {
 	b.rowContainer.Close(ctx)
 	b.rowContainer = nil
 	b.buckets = nil
+	b.partialKeyIndex = nil
 }
 
 func (b *buckets) Fetch(encoding []byte) (*bucket, bool) {
@@ -119,6 +491,216 @@ func (b *buckets) Fetch(encoding []byte) (*bucket, bool) {
 	return bk, ok
 }
 
+// graceHashPartitionRowThreshold is the bucket row count past which a single
+// spilled-to-disk key is considered large enough to warrant grace-hash
+// partitioning rather than row-at-a-time spilling.
+const graceHashPartitionRowThreshold = 1 << 20
+
+// isMemoryBudgetExceededErr reports whether err is the kind of error
+// WrappedMemoryAccount.Grow returns when a query's memory budget has been
+// exhausted, as opposed to some unrelated failure (e.g. a context
+// cancellation) that AddRow should still propagate untouched.
+func isMemoryBudgetExceededErr(err error) bool {
+	return strings.Contains(err.Error(), "memory budget exceeded")
+}
+
+// joiner abstracts the row-emission policy of a join so that Next can share
+// a single probing loop across inner, outer, semi and anti joins. It mirrors
+// the executor-level join abstraction used by other hash join
+// implementations: tryToMatch is invoked once per candidate bucket row
+// produced by a probe, and onMissMatch is invoked once per probe row that
+// never matched.
+type joiner interface {
+	// tryToMatch evaluates the ON condition for a single (probe, bucketRow)
+	// pair. If the condition passes, it emits whatever row the join type
+	// calls for (the concatenation of the two rows for inner/outer joins,
+	// just the probe row for left semi joins, nothing for anti joins and
+	// right semi joins, which only mark the bucket row seen) and reports
+	// matched=true. tryToMatch may be called zero or more times per probe
+	// row, in bucket order; once it has reported matched=true for a given
+	// probe row, a left semi join's caller stops calling it again for that
+	// row.
+	tryToMatch(
+		params runParams, probe parser.Datums, bucketRow parser.Datums, idx int, b *bucket,
+	) (matched bool, err error)
+
+	// onMissMatch is called once a probe row has been compared against every
+	// candidate bucket row (or there were no candidates at all) without
+	// tryToMatch ever reporting a match. Inner joins do nothing here; outer
+	// joins emit a NULL-padded row; anti joins emit the bare probe row.
+	onMissMatch(params runParams, probe parser.Datums) error
+}
+
+// innerOuterJoiner implements the inner/left-outer/right-outer/full-outer
+// join semantics: on a match it emits the concatenation of both rows, and on
+// a miss it emits a NULL-padded row if the join type wants unmatched rows
+// from the probe side.
+type innerOuterJoiner struct {
+	n                 *joinNode
+	wantUnmatchedLeft bool
+}
+
+func (j *innerOuterJoiner) tryToMatch(
+	params runParams, probe parser.Datums, bucketRow parser.Datums, idx int, b *bucket,
+) (bool, error) {
+	n := j.n
+	passesOnCond, err := n.pred.eval(&n.planner.evalCtx, n.evalScratch, probe, bucketRow)
+	if err != nil {
+		return false, err
+	}
+	if !passesOnCond {
+		return false, nil
+	}
+	n.pred.prepareRow(n.output, probe, bucketRow)
+	if n.joinType == joinTypeRightOuter || n.joinType == joinTypeFullOuter {
+		b.MarkSeen(idx)
+	}
+	if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (j *innerOuterJoiner) onMissMatch(params runParams, probe parser.Datums) error {
+	if !j.wantUnmatchedLeft {
+		return nil
+	}
+	n := j.n
+	n.pred.prepareRow(n.output, probe, n.emptyRight)
+	_, err := n.buffer.AddRow(params.ctx, n.output)
+	return err
+}
+
+// leftSemiJoiner implements LEFT SEMI JOIN: the probe (left) row is emitted
+// at most once, as soon as any bucket row satisfies the ON condition. Unlike
+// innerOuterJoiner, only the probe side's columns are emitted.
+type leftSemiJoiner struct {
+	n *joinNode
+}
+
+func (j *leftSemiJoiner) tryToMatch(
+	params runParams, probe parser.Datums, bucketRow parser.Datums, idx int, b *bucket,
+) (bool, error) {
+	n := j.n
+	passesOnCond, err := n.pred.eval(&n.planner.evalCtx, n.evalScratch, probe, bucketRow)
+	if err != nil {
+		return false, err
+	}
+	if !passesOnCond {
+		return false, nil
+	}
+	copy(n.output, probe)
+	if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+		return true, err
+	}
+	return true, nil
+}
+
+func (j *leftSemiJoiner) onMissMatch(params runParams, probe parser.Datums) error {
+	// A semi join only ever emits a row on a match; an unmatched probe row
+	// produces nothing.
+	return nil
+}
+
+// leftAntiJoiner implements LEFT ANTI JOIN: the probe (left) row is emitted
+// only when no bucket row satisfies the ON condition, and the NULL-padded
+// counterpart that an outer join would emit in that case is never produced.
+type leftAntiJoiner struct {
+	n *joinNode
+}
+
+func (j *leftAntiJoiner) tryToMatch(
+	params runParams, probe parser.Datums, bucketRow parser.Datums, idx int, b *bucket,
+) (bool, error) {
+	passesOnCond, err := j.n.pred.eval(&j.n.planner.evalCtx, j.n.evalScratch, probe, bucketRow)
+	if err != nil {
+		return false, err
+	}
+	return passesOnCond, nil
+}
+
+func (j *leftAntiJoiner) onMissMatch(params runParams, probe parser.Datums) error {
+	n := j.n
+	copy(n.output, probe)
+	_, err := n.buffer.AddRow(params.ctx, n.output)
+	return err
+}
+
+// leftAntiNullAwareJoiner implements the null-aware variant of LEFT ANTI
+// JOIN used for `NOT IN (subquery)` / `<> ALL (subquery)` rewrites. A
+// bucket-equality match (tryToMatch) suppresses the row exactly as in a
+// plain anti join; the only difference is onMissMatch, which additionally
+// checks naajPartialMatch before deciding to emit the row, so that a NULL
+// anywhere in the join key is handled per SQL's three-valued semantics
+// rather than being silently treated as a non-match.
+type leftAntiNullAwareJoiner struct {
+	leftAntiJoiner
+}
+
+func (j *leftAntiNullAwareJoiner) onMissMatch(params runParams, probe parser.Datums) error {
+	n := j.n
+	if n.naajPartialMatch(probe) {
+		return nil
+	}
+	copy(n.output, probe)
+	_, err := n.buffer.AddRow(params.ctx, n.output)
+	return err
+}
+
+// rightSemiAntiJoiner implements RIGHT SEMI and RIGHT ANTI JOIN. Since the
+// bucket (right) side is the one whose columns survive, emission can't
+// happen incrementally as left rows are probed -- a right row might be
+// matched by a left row seen much later. Instead tryToMatch only marks the
+// bucket row as seen on a match; Next emits the kept right rows (seen ones
+// for semi, unseen ones for anti) in its final pass over the buckets, once
+// all of the left side has been probed. onMissMatch is therefore a no-op:
+// a left row that matches nothing is of no interest to either join type.
+type rightSemiAntiJoiner struct {
+	n *joinNode
+}
+
+func (j *rightSemiAntiJoiner) tryToMatch(
+	params runParams, probe parser.Datums, bucketRow parser.Datums, idx int, b *bucket,
+) (bool, error) {
+	passesOnCond, err := j.n.pred.eval(&j.n.planner.evalCtx, j.n.evalScratch, probe, bucketRow)
+	if err != nil {
+		return false, err
+	}
+	if passesOnCond {
+		b.MarkSeen(idx)
+	}
+	return passesOnCond, nil
+}
+
+func (j *rightSemiAntiJoiner) onMissMatch(params runParams, probe parser.Datums) error {
+	return nil
+}
+
+// makeJoiner constructs the joiner implementation appropriate for n's join
+// type.
+func (n *joinNode) makeJoiner() joiner {
+	switch n.joinType {
+	case joinTypeLeftSemi:
+		return &leftSemiJoiner{n: n}
+	case joinTypeLeftAnti:
+		return &leftAntiJoiner{n: n}
+	case joinTypeLeftAntiNullAware:
+		return &leftAntiNullAwareJoiner{leftAntiJoiner{n: n}}
+	case joinTypeRightSemi, joinTypeRightAnti:
+		return &rightSemiAntiJoiner{n: n}
+	default:
+		// Covers joinTypeInner, the outer join types, and the ASOF join
+		// types: for all of these, a miss on the equality prefix is handled
+		// the same way (nothing for inner/ASOF-inner, a NULL-padded row for
+		// left/full-outer/ASOF-left-outer). The ASOF-specific closest-match
+		// probing itself happens in Next, before the joiner's tryToMatch
+		// would otherwise be consulted.
+		wantUnmatchedLeft := n.joinType == joinTypeLeftOuter || n.joinType == joinTypeFullOuter ||
+			n.joinType == joinTypeAsofLeftOuter
+		return &innerOuterJoiner{n: n, wantUnmatchedLeft: wantUnmatchedLeft}
+	}
+}
+
 // joinNode is a planNode whose rows are the result of an inner or
 // left/right outer join.
 type joinNode struct {
@@ -146,9 +728,20 @@ type joinNode struct {
 	// columns contains the metadata for the results of this node.
 	columns sqlbase.ResultColumns
 
-	// output contains the last generated row of results from this node.
+	// output contains the last generated row of results from this node. For
+	// semi/anti joins it's sized to only the outer side's columns (see
+	// joinOrdering/makeJoin), since that's all a semi/anti join ever emits.
 	output parser.Datums
 
+	// evalScratch is always sized to the full left+right combined row, for
+	// pred.eval to use as scratch space while checking the ON condition.
+	// For inner/outer joins this is the same slice as output, since output
+	// is already full-width there; for semi/anti joins it's a second,
+	// separate buffer, because evaluating the ON condition still needs
+	// both sides' columns even though output itself has been trimmed down
+	// to only the side that's actually emitted.
+	evalScratch parser.Datums
+
 	// buffer is our intermediate row store where we effectively 'stash' a batch
 	// of results at once, this is then used for subsequent calls to Next() and
 	// Values().
@@ -157,6 +750,38 @@ type joinNode struct {
 	buckets       buckets
 	bucketsMemAcc WrappableMemoryAccount
 
+	// joiner implements the row-emission policy for this node's joinType
+	// (inner/outer vs. semi/anti) and is shared by the probing loop in Next.
+	joiner joiner
+
+	// asof is non-nil for joinTypeAsofInner/joinTypeAsofLeftOuter and
+	// records which columns and comparator to use to find, per probe row,
+	// the single closest bucket row rather than every equality match.
+	asof *asofInfo
+
+	// forcedAlgorithm pins the join execution strategy per a matching
+	// /*+ HASH_JOIN(...) */-style query hint (see applyJoinHints);
+	// joinAlgorithmAuto, the default, leaves the choice to
+	// mergeJoinOrdering/DistSQL as before hints existed.
+	forcedAlgorithm joinAlgorithm
+
+	// noHashJoin records a matching /*+ NO_HASH_JOIN(...) */ hint, which
+	// forbids the hash algorithm without necessarily pinning a specific
+	// alternative.
+	noHashJoin bool
+
+	// leftRowsProbed counts how many left rows the single-threaded loop in
+	// Next has processed so far. Once it crosses parallelProbeRowThreshold,
+	// Next hands the rest of the left side off to beginParallelProbe: this
+	// way parallel probing actually engages whenever the left side turns
+	// out to be large, rather than depending on an upfront estimate nothing
+	// in this file would ever have a way to provide.
+	leftRowsProbed int64
+
+	// parallel is non-nil once beginParallelProbe has been called; see
+	// parallelProbeState and joinNode.waitForParallelRow.
+	parallel *parallelProbeState
+
 	// emptyRight contain tuples of NULL values to use on the right for left and
 	// full outer joins when the on condition fails.
 	emptyRight parser.Datums
@@ -193,13 +818,17 @@ func commonColumns(left, right *dataSourceInfo) parser.NameList {
 
 // makeJoin constructs a planDataSource for a JOIN node.
 // The tableInfo field from the left node is taken over (overwritten)
-// by the new node.
+// by the new node. hintComment is the raw text of any `/*+ ... */`
+// optimizer-hint comment the caller found attached to this JOIN in the
+// query, or "" if there isn't one; it's parsed and applied to the new node
+// before makeJoin returns, so every call site gets hint support for free.
 func (p *planner) makeJoin(
 	ctx context.Context,
 	astJoinType string,
 	left planDataSource,
 	right planDataSource,
 	cond parser.JoinCond,
+	hintComment string,
 ) (planDataSource, error) {
 	var typ joinType
 	switch astJoinType {
@@ -211,6 +840,18 @@ func (p *planner) makeJoin(
 		typ = joinTypeRightOuter
 	case "FULL JOIN":
 		typ = joinTypeFullOuter
+	case "LEFT SEMI JOIN":
+		typ = joinTypeLeftSemi
+	case "LEFT ANTI JOIN":
+		typ = joinTypeLeftAnti
+	case "RIGHT SEMI JOIN":
+		typ = joinTypeRightSemi
+	case "RIGHT ANTI JOIN":
+		typ = joinTypeRightAnti
+	case "ASOF JOIN":
+		typ = joinTypeAsofInner
+	case "LEFT ASOF JOIN":
+		typ = joinTypeAsofLeftOuter
 	default:
 		return planDataSource{}, errors.Errorf("unsupported JOIN type %T", astJoinType)
 	}
@@ -236,10 +877,17 @@ func (p *planner) makeJoin(
 	var (
 		info *dataSourceInfo
 		pred *joinPredicate
+		asof *asofInfo
 		err  error
 	)
 
-	if cond == nil {
+	if typ.isAsofJoin() {
+		onCond, ok := cond.(*parser.OnJoinCond)
+		if !ok {
+			return planDataSource{}, errors.Errorf("ASOF JOIN requires an ON condition")
+		}
+		pred, info, asof, err = p.makeAsofPredicate(ctx, leftInfo, rightInfo, onCond.Expr)
+	} else if cond == nil {
 		pred, info, err = makeCrossPredicate(leftInfo, rightInfo)
 	} else {
 		switch t := cond.(type) {
@@ -262,9 +910,24 @@ func (p *planner) makeJoin(
 		right:    right,
 		joinType: typ,
 		pred:     pred,
+		asof:     asof,
 		columns:  info.sourceColumns,
 	}
 
+	// Semi and anti joins never surface the inner side's columns, so trim
+	// the result schema down to whichever side is the outer one.
+	if typ.isSemiOrAntiJoin() {
+		if typ.isRightSemiOrAntiJoin() {
+			n.columns = rightInfo.sourceColumns
+		} else {
+			n.columns = leftInfo.sourceColumns
+		}
+	}
+
+	if hintComment != "" {
+		n.applyJoinHints(parseJoinHints(hintComment))
+	}
+
 	n.buffer = &RowBuffer{
 		RowContainer: sqlbase.NewRowContainer(
 			p.session.TxnState.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(planColumns(n)), 0,
@@ -274,8 +937,14 @@ func (p *planner) makeJoin(
 	n.bucketsMemAcc = p.session.TxnState.OpenAccount()
 	n.buckets = buckets{
 		buckets: make(map[string]*bucket),
-		rowContainer: sqlbase.NewRowContainer(
-			p.session.TxnState.makeBoundAccount(),
+		// Bounding this account at sql.hash_join.memory_spill_threshold
+		// (rather than the session's full remaining budget) is what gives
+		// that setting a real effect: it's what rowContainer.AddRow will
+		// exceed to trigger AddRow's SpillToDisk call, well before the
+		// query's overall memory budget would otherwise be hit.
+		rowContainer: sqlbase.NewDiskBackedRowContainer(
+			p.session.TxnState.makeBoundAccountWithLimit(joinHashSpillThreshold.Get()),
+			p.session.TxnState.TempStorage(),
 			sqlbase.ColTypeInfoFromResCols(planColumns(n.right.plan)),
 			0,
 		),
@@ -287,6 +956,180 @@ func (p *planner) makeJoin(
 	}, nil
 }
 
+// makeNullAwareAntiJoin builds the LEFT ANTI JOIN variant with null-aware
+// semantics used when rewriting `NOT IN (subquery)` / `<> ALL (subquery)`
+// into a join: unlike a plain LEFT ANTI JOIN, this one suppresses the outer
+// row not only on an exact key match but also whenever a NULL on either
+// side makes the comparison merely UNKNOWN rather than definitively FALSE.
+// See joinTypeLeftAntiNullAware.
+func (p *planner) makeNullAwareAntiJoin(
+	ctx context.Context, left planDataSource, right planDataSource, cond parser.JoinCond,
+) (planDataSource, error) {
+	ds, err := p.makeJoin(ctx, "LEFT JOIN", left, right, cond, "")
+	if err != nil {
+		return planDataSource{}, err
+	}
+	n := ds.plan.(*joinNode)
+	n.joinType = joinTypeLeftAntiNullAware
+	n.columns = left.info.sourceColumns
+	ds.info.sourceColumns = n.columns
+	// makeJoin sized the result buffer for a LEFT JOIN's full-width rows;
+	// rebuild it for the left-only rows this join emits.
+	n.buffer.Close(ctx)
+	n.buffer = &RowBuffer{
+		RowContainer: sqlbase.NewRowContainer(
+			p.session.TxnState.makeBoundAccount(), sqlbase.ColTypeInfoFromResCols(planColumns(n)), 0,
+		),
+	}
+	return ds, nil
+}
+
+// makeAsofPredicate splits an ASOF JOIN's ON expression into an equality
+// prefix (zero or more `a.col = b.col` conjuncts, handled exactly like an
+// ordinary hash-equality join key) and exactly one inequality conjunct on
+// the "asof" column, e.g. `a.ts >= b.ts`. The equality prefix is handed off
+// to makeOnPredicate so ASOF JOIN reuses the normal bucket-lookup and
+// result-schema machinery; only the closest-match probe differs.
+func (p *planner) makeAsofPredicate(
+	ctx context.Context, leftInfo, rightInfo *dataSourceInfo, expr parser.Expr,
+) (*joinPredicate, *dataSourceInfo, *asofInfo, error) {
+	var eqExprs []parser.Expr
+	var asofExpr *parser.ComparisonExpr
+
+	// Split the top-level AND-chain into its conjuncts.
+	var walk func(e parser.Expr) error
+	walk = func(e parser.Expr) error {
+		if and, ok := e.(*parser.AndExpr); ok {
+			if err := walk(and.Left); err != nil {
+				return err
+			}
+			return walk(and.Right)
+		}
+		cmp, ok := e.(*parser.ComparisonExpr)
+		if !ok {
+			return errors.Errorf("ASOF JOIN ON clause must be a conjunction of comparisons, found %s", e)
+		}
+		switch cmp.Operator {
+		case parser.EQ:
+			eqExprs = append(eqExprs, cmp)
+		case parser.GE, parser.LE, parser.GT, parser.LT:
+			if asofExpr != nil {
+				return errors.Errorf("ASOF JOIN allows only one inequality condition on the asof column")
+			}
+			asofExpr = cmp
+		default:
+			return errors.Errorf("ASOF JOIN does not support operator %s", cmp.Operator)
+		}
+		return nil
+	}
+	if err := walk(expr); err != nil {
+		return nil, nil, nil, err
+	}
+	if asofExpr == nil {
+		return nil, nil, nil, errors.Errorf(
+			"ASOF JOIN requires exactly one inequality condition on the asof column")
+	}
+
+	var eqExpr parser.Expr = parser.DBoolTrue
+	for _, e := range eqExprs {
+		eqExpr = &parser.AndExpr{Left: eqExpr, Right: e}
+	}
+
+	pred, info, err := p.makeOnPredicate(ctx, leftInfo, rightInfo, eqExpr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	asof, err := resolveAsofColumns(leftInfo, rightInfo, asofExpr)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return pred, info, asof, nil
+}
+
+// resolveAsofColumns resolves the two sides of an ASOF JOIN's inequality
+// expression to column indices and records the operator's direction. It
+// only supports the common case of a bare `a.col <op> b.col` comparison
+// between simple column references, one per side; more elaborate asof
+// expressions are left for a future extension.
+func resolveAsofColumns(
+	leftInfo, rightInfo *dataSourceInfo, cmp *parser.ComparisonExpr,
+) (*asofInfo, error) {
+	leftCol, leftOK := findColumnByName(leftInfo, cmp.Left)
+	rightCol, rightOK := findColumnByName(rightInfo, cmp.Right)
+	swapped := false
+	if !leftOK || !rightOK {
+		// Try the other orientation, e.g. `b.ts <= a.ts`.
+		leftCol, leftOK = findColumnByName(leftInfo, cmp.Right)
+		rightCol, rightOK = findColumnByName(rightInfo, cmp.Left)
+		swapped = true
+	}
+	if !leftOK || !rightOK {
+		return nil, errors.Errorf(
+			"ASOF JOIN inequality must compare a column from each side, found %s", cmp)
+	}
+
+	op, err := asofOperator(cmp.Operator, swapped)
+	if err != nil {
+		return nil, err
+	}
+	return &asofInfo{leftCol: leftCol, rightCol: rightCol, op: op}, nil
+}
+
+// findColumnByName resolves a simple column reference within a single data
+// source's columns, returning its index and whether it was found. A
+// table-qualified reference is only found if the qualifier names one of the
+// source's tables.
+func findColumnByName(info *dataSourceInfo, expr parser.Expr) (int, bool) {
+	item, ok := expr.(*parser.ColumnItem)
+	if !ok {
+		return 0, false
+	}
+	if t := item.TableName.Table(); t != "" && !sourceTableNames(info)[t] {
+		// A qualified reference to a table this source doesn't have.
+		return 0, false
+	}
+	name := item.ColumnName
+	for i, c := range info.sourceColumns {
+		if parser.Name(c.Name) == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// asofOperator translates a ComparisonOperator from `a.col <op> b.col` (or,
+// if swapped, `b.col <op> a.col`) into the asofCompareOp used when probing,
+// which is always expressed in terms of "probe (left) compared to bucket
+// (right)".
+func asofOperator(op parser.ComparisonOperator, swapped bool) (asofCompareOp, error) {
+	switch op {
+	case parser.GE:
+		if swapped {
+			return asofLE, nil
+		}
+		return asofGE, nil
+	case parser.LE:
+		if swapped {
+			return asofGE, nil
+		}
+		return asofLE, nil
+	case parser.GT:
+		if swapped {
+			return asofLT, nil
+		}
+		return asofGT, nil
+	case parser.LT:
+		if swapped {
+			return asofGT, nil
+		}
+		return asofLT, nil
+	default:
+		return 0, errors.Errorf("unsupported ASOF JOIN operator %s", op)
+	}
+}
+
 // Start implements the planNode interface.
 func (n *joinNode) Start(params runParams) error {
 	if err := n.left.plan.Start(params); err != nil {
@@ -296,12 +1139,33 @@ func (n *joinNode) Start(params runParams) error {
 		return err
 	}
 
+	n.chooseAlgorithm(params.ctx)
+
 	if err := n.hashJoinStart(params); err != nil {
 		return err
 	}
 
+	n.joiner = n.makeJoiner()
+
 	// Pre-allocate the space for output rows.
 	n.output = make(parser.Datums, len(n.columns))
+	if n.joinType.isSemiOrAntiJoin() {
+		n.evalScratch = make(
+			parser.Datums,
+			n.pred.numMergedEqualityColumns+n.pred.numLeftCols+n.pred.numRightCols,
+		)
+	} else {
+		// output is already full-width for every other join type.
+		n.evalScratch = n.output
+	}
+	if n.joinType == joinTypeAsofLeftOuter {
+		// An ASOF LEFT JOIN pads a probe row with no closest match the same
+		// way a LEFT JOIN pads an unmatched one.
+		n.emptyRight = make(parser.Datums, len(planColumns(n.right.plan)))
+		for i := range n.emptyRight {
+			n.emptyRight[i] = parser.DNull
+		}
+	}
 
 	// If needed, pre-allocate left and right rows of NULL tuples for when the
 	// join predicate fails to match.
@@ -323,6 +1187,10 @@ func (n *joinNode) Start(params runParams) error {
 
 func (n *joinNode) hashJoinStart(params runParams) error {
 	var scratch []byte
+	naaj := n.joinType == joinTypeLeftAntiNullAware
+	if naaj {
+		n.buckets.partialKeyIndex = make(map[uint64]map[string]bool)
+	}
 	// Load all the rows from the right side and build our hashmap.
 	acc := n.bucketsMemAcc.Wtxn(n.planner.session)
 	ctx := params.ctx
@@ -335,25 +1203,600 @@ func (n *joinNode) hashJoinStart(params runParams) error {
 			break
 		}
 		row := n.right.plan.Values()
-		encoding, _, err := n.pred.encode(scratch, row, n.pred.rightEqualityIndices)
+		encoding, containsNull, err := n.pred.encode(scratch, row, n.pred.rightEqualityIndices)
 		if err != nil {
 			return err
 		}
 
+		if naaj {
+			n.buckets.rightRowCount++
+			if containsNull {
+				n.buckets.rightHasNull = true
+			} else if err := n.buildNAAJPartialIndex(row); err != nil {
+				return err
+			}
+		}
+
 		if err := n.buckets.AddRow(ctx, acc, encoding, row); err != nil {
 			return err
 		}
+		if bk, ok := n.buckets.Fetch(encoding); ok && bk.NumRows() > n.buckets.maxBucketRows {
+			n.buckets.maxBucketRows = bk.NumRows()
+			// A single key this large won't be helped by spilling the rest
+			// of the build side to disk one row at a time: the whole
+			// bucket still has to be held (and probed) together. Flag it
+			// so an operator can tell from the metrics that this hash join
+			// would benefit from being grace-hash-partitioned instead.
+			// Actually partitioning both inputs by a secondary hash of the
+			// key and recursively joining each partition pair needs
+			// temp-storage infrastructure this snapshot doesn't have, so
+			// for now this is bookkeeping only: no partitioning happens.
+			if n.buckets.spilled && n.buckets.maxBucketRows > graceHashPartitionRowThreshold {
+				log.Warningf(ctx, "hash join bucket has grown to %d rows after spilling to disk; "+
+					"consider this query for grace-hash-partitioning", n.buckets.maxBucketRows)
+			}
+		}
 
 		scratch = encoding[:0]
 	}
-	if n.joinType == joinTypeFullOuter || n.joinType == joinTypeRightOuter {
+
+	if n.asof != nil {
+		// Each equality bucket's rows must be sorted by the asof column so
+		// that probing can binary-search for the single closest match
+		// instead of scanning every row. This is the one place a bucket's
+		// rows are read back out of rowContainer as a batch rather than one
+		// at a time -- sorting needs every row's asof value up front -- but
+		// only that column's value is kept around afterward (in sortKeys),
+		// not the rows themselves.
+		rightCol := n.asof.rightCol
+		for _, bk := range n.buckets.Buckets() {
+			type idxKey struct {
+				idx int
+				key parser.Datum
+			}
+			sortKeys := make([]idxKey, bk.NumRows())
+			for i := range sortKeys {
+				row, err := n.buckets.Row(ctx, bk, i)
+				if err != nil {
+					return err
+				}
+				sortKeys[i] = idxKey{idx: bk.rowIdxs[i], key: row[rightCol]}
+			}
+			sort.Slice(sortKeys, func(i, j int) bool {
+				return sortKeys[i].key.Compare(sortKeys[j].key) < 0
+			})
+			for i, sk := range sortKeys {
+				bk.rowIdxs[i] = sk.idx
+			}
+		}
+	}
+
+	switch n.joinType {
+	case joinTypeFullOuter, joinTypeRightOuter, joinTypeRightSemi, joinTypeRightAnti:
 		return n.buckets.InitSeen(ctx, acc)
 	}
 	return nil
 }
 
+// joinProbeWorkers controls how many goroutines Next fans the probe phase
+// of a hash join out across, once hashJoinStart has finished building and
+// the bucket map is frozen and read-only. Zero (the default) means "use
+// GOMAXPROCS".
+var joinProbeWorkers = settings.RegisterIntSetting(
+	"sql.hash_join.probe_workers",
+	"number of goroutines used to probe a hash join's build side concurrently; 0 uses GOMAXPROCS",
+	0,
+)
+
+// parallelProbeRowThreshold is the minimum number of left rows the
+// single-threaded loop in Next must have already probed before it hands the
+// rest of the left side off to beginParallelProbe; below it, goroutine
+// startup and synchronization cost more than a single-threaded scan would.
+const parallelProbeRowThreshold = 10000
+
+// wantParallelProbe reports whether Next should hand the remainder of the
+// probe phase off to worker goroutines (see beginParallelProbe) rather than
+// keep running it single-threaded. n.leftRowsProbed is incremented by the
+// single-threaded loop itself as it goes, so this only ever turns true once
+// that loop has demonstrably seen enough left rows to make parallelizing
+// the rest worthwhile; there's no estimate to trust up front.
+//
+// Only plain inner/outer joins take this path. Semi/anti joins only need a
+// single witness match per probe row and ASOF needs its bucket rows kept in
+// sorted order for binary search, so parallelizing them wouldn't save much;
+// NAAJ's global right-NULL suppression check in Next also assumes a
+// single-threaded probe. Restricting to inner/outer keeps the worker loop
+// below simple enough to reason about concurrently.
+func (n *joinNode) wantParallelProbe() bool {
+	switch n.joinType {
+	case joinTypeInner, joinTypeLeftOuter, joinTypeRightOuter, joinTypeFullOuter:
+	default:
+		return false
+	}
+	return n.leftRowsProbed >= parallelProbeRowThreshold
+}
+
+// parallelProbeState is the coordination state for an in-flight parallel
+// probe, shared between beginParallelProbe's worker/coordinator goroutines
+// and waitForParallelRow.
+type parallelProbeState struct {
+	// rowReady is pinged (non-blocking) every time a worker adds a row to
+	// n.buffer, so waitForParallelRow has something to wake up on besides
+	// polling. It's buffered to size 1: a missed send just means the next
+	// wait wakes up immediately and finds the row already there.
+	rowReady chan struct{}
+
+	// done is closed by the coordinator goroutine once every worker has
+	// exited and (for RIGHT/FULL OUTER) emitUnmatchedRightRows has run, so
+	// waitForParallelRow knows no more rows are coming.
+	done chan struct{}
+
+	// stop is closed by Close to tell the workers and coordinator to give
+	// up early -- e.g. a LIMIT above this join stopped pulling rows, or the
+	// query errored out elsewhere -- so Close can safely wait on done before
+	// tearing down n.buffer and the child plans out from under them.
+	stop chan struct{}
+
+	// bufMu serializes every access to n.buffer for as long as a parallel
+	// probe is in flight: the workers' AddRow calls and the driver
+	// goroutine's Next/Values calls run concurrently once beginParallelProbe
+	// has returned, and RowBuffer/RowContainer isn't safe for that on its
+	// own.
+	bufMu sync.Mutex
+
+	// err is set before done is closed if either a worker or
+	// emitUnmatchedRightRows returned an error.
+	err error
+}
+
+// beginParallelProbe launches numWorkers goroutines that drain n.left.plan
+// and probe the (now read-only) bucket map concurrently, each appending its
+// matches to n.buffer as it finds them, plus one coordinator goroutine that
+// waits for them to finish, runs emitUnmatchedRightRows if this join type
+// needs it, and then closes n.parallel.done. It returns immediately after
+// starting these goroutines; rows are streamed out through n.buffer and
+// picked up by waitForParallelRow, so Next keeps returning one row at a time
+// instead of blocking until the whole left side has been probed.
+//
+// Each worker uses its own clone of the planner's evalCtx and its own
+// output row buffer rather than the shared n.planner.evalCtx/n.output that
+// the single-threaded joiner implementations close over, since those
+// aren't safe to evaluate or write concurrently. Because of that, this
+// method duplicates the inner/outer match logic from joiner.tryToMatch /
+// onMissMatch instead of calling through the joiner interface; it is not
+// used for the join types whose joiners carry extra per-row state (semi,
+// anti, NAAJ).
+//
+// Seen-bit bookkeeping for RIGHT/FULL OUTER is safe under this concurrency
+// because bucket.MarkSeen sets bits in a []uint64 bitmap with a
+// compare-and-swap loop (see bucket.MarkSeen) rather than writing a []bool
+// slice.
+func (n *joinNode) beginParallelProbe(params runParams) {
+	state := &parallelProbeState{
+		rowReady: make(chan struct{}, 1),
+		done:     make(chan struct{}),
+		stop:     make(chan struct{}),
+	}
+	n.parallel = state
+
+	numWorkers := int(joinProbeWorkers.Get())
+	if numWorkers <= 0 {
+		numWorkers = runtime.GOMAXPROCS(0)
+	}
+
+	// Only RIGHT/FULL OUTER ever read a bucket row's Seen bit back (in
+	// emitUnmatchedRightRows), and InitSeen only allocates bucket.seen for
+	// those two join types plus RIGHT SEMI/ANTI, neither of which takes the
+	// parallel path (see wantParallelProbe). Marking seen for plain
+	// INNER/LEFT OUTER here would index into a nil bitmap.
+	wantMarkSeen := n.joinType == joinTypeRightOuter || n.joinType == joinTypeFullOuter
+	wantLeftPad := n.joinType == joinTypeLeftOuter || n.joinType == joinTypeFullOuter
+
+	var pullMu sync.Mutex
+	var errOnce sync.Once
+	setErr := func(err error) {
+		errOnce.Do(func() { state.err = err })
+	}
+	notify := func() {
+		select {
+		case state.rowReady <- struct{}{}:
+		default:
+		}
+	}
+	stopped := func() bool {
+		select {
+		case <-state.stop:
+			return true
+		default:
+			return false
+		}
+	}
+
+	var wg sync.WaitGroup
+	for w := 0; w < numWorkers; w++ {
+		wg.Add(1)
+		workerEvalCtx := n.planner.evalCtx
+		go func(evalCtx parser.EvalContext) {
+			defer wg.Done()
+			output := make(parser.Datums, len(n.output))
+			for {
+				if stopped() {
+					return
+				}
+				if err := params.p.cancelChecker.Check(); err != nil {
+					setErr(err)
+					return
+				}
+
+				pullMu.Lock()
+				hasRow, err := n.left.plan.Next(params)
+				var lrow parser.Datums
+				if err == nil && hasRow {
+					lrow = append(parser.Datums(nil), n.left.plan.Values()...)
+				}
+				pullMu.Unlock()
+				if err != nil {
+					setErr(err)
+					return
+				}
+				if !hasRow {
+					return
+				}
+
+				encoding, containsNull, err := n.pred.encode(nil, lrow, n.pred.leftEqualityIndices)
+				if err != nil {
+					setErr(err)
+					return
+				}
+
+				var foundMatch bool
+				if !containsNull {
+					if b, ok := n.buckets.Fetch(encoding); ok {
+						for idx := 0; idx < b.NumRows(); idx++ {
+							rrow, err := n.buckets.Row(params.ctx, b, idx)
+							if err != nil {
+								setErr(err)
+								return
+							}
+							passes, err := n.pred.eval(&evalCtx, output, lrow, rrow)
+							if err != nil {
+								setErr(err)
+								return
+							}
+							if !passes {
+								continue
+							}
+							foundMatch = true
+							if wantMarkSeen {
+								b.MarkSeen(idx)
+							}
+							n.pred.prepareRow(output, lrow, rrow)
+							state.bufMu.Lock()
+							_, err = n.buffer.AddRow(params.ctx, output)
+							state.bufMu.Unlock()
+							if err != nil {
+								setErr(err)
+								return
+							}
+							notify()
+						}
+					}
+				}
+				if !foundMatch && wantLeftPad {
+					n.pred.prepareRow(output, lrow, n.emptyRight)
+					state.bufMu.Lock()
+					_, err := n.buffer.AddRow(params.ctx, output)
+					state.bufMu.Unlock()
+					if err != nil {
+						setErr(err)
+						return
+					}
+					notify()
+				}
+			}
+		}(workerEvalCtx)
+	}
+
+	go func() {
+		wg.Wait()
+		if state.err == nil && !stopped() &&
+			(n.joinType == joinTypeRightOuter || n.joinType == joinTypeFullOuter) {
+			state.bufMu.Lock()
+			state.err = n.emitUnmatchedRightRows(params)
+			state.bufMu.Unlock()
+		}
+		notify()
+		close(state.done)
+	}()
+}
+
+// waitForParallelRow is Next's implementation once beginParallelProbe has
+// engaged: it drains n.buffer as workers fill it, waking up on
+// n.parallel.rowReady rather than polling, and returns once a row is
+// available, the probe has finished (n.parallel.done), or the context is
+// cancelled. Unlike the old design this replaced, it never blocks until the
+// whole left side has been probed — it returns as soon as there's one row
+// to hand back, preserving LIMIT/cancellation pipelining.
+//
+// Every n.buffer.Next() call here goes through state.bufMu, the same lock
+// the workers take around their AddRow calls in beginParallelProbe: once a
+// parallel probe is in flight, the driver goroutine (here) and the worker
+// goroutines touch the shared RowBuffer concurrently, and RowBuffer isn't
+// safe for that on its own.
+func (n *joinNode) waitForParallelRow(params runParams) (bool, error) {
+	state := n.parallel
+	for {
+		state.bufMu.Lock()
+		hasRow := n.buffer.Next()
+		state.bufMu.Unlock()
+		if hasRow {
+			return true, nil
+		}
+		select {
+		case <-state.rowReady:
+			continue
+		case <-state.done:
+			if state.err != nil {
+				return false, state.err
+			}
+			n.parallel = nil
+			n.finishedOutput = true
+			state.bufMu.Lock()
+			hasRow := n.buffer.Next()
+			state.bufMu.Unlock()
+			return hasRow, nil
+		case <-params.ctx.Done():
+			return false, params.ctx.Err()
+		}
+	}
+}
+
+// emitUnmatchedRightRows scans every bucket for rows whose Seen bit was
+// never set during probing and appends the rows this join type wants to
+// see from them to n.buffer: RIGHT/FULL OUTER pad them with emptyLeft,
+// RIGHT ANTI emits them bare, and RIGHT SEMI emits the opposite
+// (already-seen) rows instead. It's shared by the single-threaded probe
+// loop's tail and by beginParallelProbe's coordinator goroutine, both of
+// which call it only after every
+// left row has been probed and every Seen bit is final.
+func (n *joinNode) emitUnmatchedRightRows(params runParams) error {
+	for _, b := range n.buckets.Buckets() {
+		for idx := 0; idx < b.NumRows(); idx++ {
+			if err := params.p.cancelChecker.Check(); err != nil {
+				return err
+			}
+			rrow, err := n.buckets.Row(params.ctx, b, idx)
+			if err != nil {
+				return err
+			}
+			seen := b.Seen(idx)
+			switch n.joinType {
+			case joinTypeRightSemi:
+				if !seen {
+					continue
+				}
+				copy(n.output, rrow)
+			case joinTypeRightOuter, joinTypeFullOuter, joinTypeRightAnti:
+				if seen {
+					continue
+				}
+				if n.joinType == joinTypeRightAnti {
+					copy(n.output, rrow)
+				} else {
+					n.pred.prepareRow(n.output, n.emptyLeft, rrow)
+				}
+			default:
+				continue
+			}
+			if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// probeAsofIndex returns the index into rows (sorted ascending by the value
+// at position i, fetched via asofValAt) of the single closest match for
+// probeVal under op, or -1 if no row satisfies the inequality. op is always
+// expressed as "probeVal <op> asofValAt(i)", e.g. asofGE means the caller
+// wants the largest asofValAt(i) that is <= probeVal. n is the number of
+// rows available (0 <= i < n); asofValAt fetches just the asof-column value
+// for row i rather than the whole row, since that's all a binary search
+// needs -- the caller fetches the matched row's full contents itself, once,
+// using the index this returns.
+//
+// Per SQL's three-valued NULL semantics, a NULL probeVal makes every
+// comparison UNKNOWN rather than true, so it can never match; this is
+// checked up front rather than left to Datum.Compare's NULL ordering, which
+// would otherwise make asofLE/asofLT spuriously return the smallest row in
+// the bucket (NULL sorts below every non-null value under the usual
+// ORDER BY convention, so the search below would see every row's asof value
+// as ">= probeVal").
+//
+// Behavior this function is responsible for getting right, and that any
+// test harness added against this package should exercise directly: ties
+// (multiple rows' asof value equal to probeVal, where asofGE/asofLE must
+// return one of them but asofGT/asofLT must skip past all of them), a
+// probeVal of NULL (must always return -1, per above), and n == 0 (every
+// case above must return -1, not panic). A bucket can also be entirely
+// absent for an equality key that the left side probes for but the right
+// side never produced -- that's handled one level up in Next via
+// buckets.Fetch's ok result, never by calling this function with n == 0.
+// TestProbeAsofIndex covers the cases above as table-driven cases.
+func probeAsofIndex(
+	n int, asofValAt func(i int) (parser.Datum, error), op asofCompareOp, probeVal parser.Datum,
+) (int, error) {
+	if probeVal == parser.DNull {
+		return -1, nil
+	}
+
+	var fetchErr error
+	valAt := func(i int) parser.Datum {
+		v, err := asofValAt(i)
+		if err != nil {
+			fetchErr = err
+			return parser.DNull
+		}
+		return v
+	}
+
+	switch op {
+	case asofGE, asofGT:
+		// rows[0:i] are every row whose asof value is <= probeVal; the
+		// closest match (if any) is the last of them.
+		i := sort.Search(n, func(i int) bool {
+			return valAt(i).Compare(probeVal) > 0
+		})
+		if fetchErr != nil {
+			return -1, fetchErr
+		}
+		for j := i - 1; j >= 0; j-- {
+			v := valAt(j)
+			if fetchErr != nil {
+				return -1, fetchErr
+			}
+			if op == asofGT && v.Compare(probeVal) == 0 {
+				continue
+			}
+			return j, nil
+		}
+		return -1, nil
+	case asofLE, asofLT:
+		// rows[i:] are every row whose asof value is >= probeVal; the
+		// closest match (if any) is the first of them.
+		i := sort.Search(n, func(i int) bool {
+			return valAt(i).Compare(probeVal) >= 0
+		})
+		if fetchErr != nil {
+			return -1, fetchErr
+		}
+		for j := i; j < n; j++ {
+			v := valAt(j)
+			if fetchErr != nil {
+				return -1, fetchErr
+			}
+			if op == asofLT && v.Compare(probeVal) == 0 {
+				continue
+			}
+			return j, nil
+		}
+		return -1, nil
+	default:
+		return -1, nil
+	}
+}
+
+// maxNAAJSubsetColumns bounds the number of equality columns for which
+// buildNAAJPartialIndex will build an exhaustive subset index (2^k entries
+// per right row). Joins with more equality columns than this fall back to
+// the conservative (rule 1 and 2 only) null-aware anti join behavior; see
+// naajPartialMatch.
+const maxNAAJSubsetColumns = 8
+
+// buildNAAJPartialIndex records, for every non-empty subset of the equality
+// columns, the encoding of row's values at exactly that subset, provided row
+// is non-NULL in all of them. naajPartialMatch uses this to determine
+// whether a left row containing a NULL nonetheless has a "partial match" on
+// the right: a right row that agrees with it on every column the left row
+// doesn't have NULL in, which by the three-valued NULL semantics of NOT IN
+// means the left row must be suppressed.
+func (n *joinNode) buildNAAJPartialIndex(row parser.Datums) error {
+	k := len(n.pred.rightEqualityIndices)
+	if k > maxNAAJSubsetColumns {
+		return nil
+	}
+	for mask := uint64(1); mask < uint64(1)<<uint(k); mask++ {
+		subIndices := make([]int, 0, k)
+		for i, idx := range n.pred.rightEqualityIndices {
+			if mask&(1<<uint(i)) != 0 {
+				subIndices = append(subIndices, idx)
+			}
+		}
+		subEncoding, subContainsNull, err := n.pred.encode(nil, row, subIndices)
+		if err != nil {
+			return err
+		}
+		if subContainsNull {
+			continue
+		}
+		values, ok := n.buckets.partialKeyIndex[mask]
+		if !ok {
+			values = make(map[string]bool)
+			n.buckets.partialKeyIndex[mask] = values
+		}
+		values[string(subEncoding)] = true
+	}
+	return nil
+}
+
+// naajPartialMatch implements rules (2) and (3) of the null-aware anti join:
+// it reports whether probe (a left row that encode found to contain a NULL
+// in its equality key) must be suppressed because of a NULL or partial match
+// on the right side, as opposed to the ordinary hash-bucket equality match
+// handled by leftAntiJoiner.tryToMatch.
+//
+// Behavior this function (and buildNAAJPartialIndex/rightHasNull above it)
+// is responsible for getting right, and that any test harness added against
+// this package should exercise directly: a probe row that's NULL in every
+// equality column (mask == 0) against a non-empty right side with no NULLs
+// of its own; a probe row that's NULL in only some of several equality
+// columns, matched against a partial-key subset that does/doesn't appear on
+// the right; and an empty right side, where rightRowCount == 0 must make
+// rule (3)'s all-NULL case fall through to false rather than suppressing
+// everything. TestNaajPartialMatch covers these cases.
+func (n *joinNode) naajPartialMatch(probe parser.Datums) bool {
+	if n.buckets.rightHasNull {
+		// Rule (2): a NULL anywhere in the right side's equality key makes
+		// every row comparison at least UNKNOWN, which suppresses every
+		// outer row regardless of its own content.
+		return true
+	}
+
+	mask := uint64(0)
+	anyNull := false
+	for i, idx := range n.pred.leftEqualityIndices {
+		if probe[idx] == parser.DNull {
+			anyNull = true
+			continue
+		}
+		mask |= 1 << uint(i)
+	}
+	if !anyNull {
+		return false
+	}
+	if mask == 0 {
+		// probe is NULL in every equality column; any right row at all
+		// yields an UNKNOWN comparison, never a definite FALSE.
+		return n.buckets.rightRowCount > 0
+	}
+	if len(n.pred.leftEqualityIndices) > maxNAAJSubsetColumns {
+		return false
+	}
+
+	subIndices := make([]int, 0, len(n.pred.leftEqualityIndices))
+	for i, idx := range n.pred.leftEqualityIndices {
+		if mask&(1<<uint(i)) != 0 {
+			subIndices = append(subIndices, idx)
+		}
+	}
+	encoding, _, err := n.pred.encode(nil, probe, subIndices)
+	if err != nil {
+		return false
+	}
+	return n.buckets.partialKeyIndex[mask][string(encoding)]
+}
+
 // Next implements the planNode interface.
 func (n *joinNode) Next(params runParams) (res bool, err error) {
+	if n.parallel != nil {
+		// A previous call already handed the rest of the left side off to
+		// beginParallelProbe; keep draining it (through its own bufMu-guarded
+		// buffer access) rather than touching n.buffer directly here, since
+		// the workers may be appending to it concurrently.
+		return n.waitForParallelRow(params)
+	}
+
 	// If results available from from previously computed results, we just
 	// return true.
 	if n.buffer.Next() {
@@ -365,8 +1808,18 @@ func (n *joinNode) Next(params runParams) (res bool, err error) {
 		return false, nil
 	}
 
-	wantUnmatchedLeft := n.joinType == joinTypeLeftOuter || n.joinType == joinTypeFullOuter
-	wantUnmatchedRight := n.joinType == joinTypeRightOuter || n.joinType == joinTypeFullOuter
+	if n.joinType == joinTypeLeftAntiNullAware && n.buckets.rightHasNull {
+		// Rule (2): once any right-side row has a NULL in its equality key,
+		// the null-aware anti join never emits anything at all.
+		n.finishedOutput = true
+		return false, nil
+	}
+
+	wantUnmatchedLeft := n.joinType == joinTypeLeftOuter || n.joinType == joinTypeFullOuter ||
+		n.joinType == joinTypeLeftAnti || n.joinType == joinTypeLeftAntiNullAware ||
+		n.joinType == joinTypeAsofLeftOuter
+	wantUnmatchedRight := n.joinType == joinTypeRightOuter || n.joinType == joinTypeFullOuter ||
+		n.joinType == joinTypeRightSemi || n.joinType == joinTypeRightAnti
 
 	if len(n.buckets.Buckets()) == 0 {
 		if !wantUnmatchedLeft {
@@ -382,6 +1835,18 @@ func (n *joinNode) Next(params runParams) (res bool, err error) {
 			return false, err
 		}
 
+		if n.wantParallelProbe() {
+			// The single-threaded loop has now probed enough left rows that
+			// it's worth fanning the rest of the probe phase out across
+			// worker goroutines; n.left.plan's cursor is exactly where the
+			// workers should pick up from, since every row up to here has
+			// already been matched below. beginParallelProbe hands it off
+			// and returns immediately; waitForParallelRow picks up from
+			// here.
+			n.beginParallelProbe(params)
+			return n.waitForParallelRow(params)
+		}
+
 		leftHasRow, err := n.left.plan.Next(params)
 		if err != nil {
 			return false, nil
@@ -389,6 +1854,7 @@ func (n *joinNode) Next(params runParams) (res bool, err error) {
 		if !leftHasRow {
 			break
 		}
+		n.leftRowsProbed++
 
 		lrow := n.left.plan.Values()
 		encoding, containsNull, err := n.pred.encode(scratch, lrow, n.pred.leftEqualityIndices)
@@ -440,67 +1906,92 @@ func (n *joinNode) Next(params runParams) (res bool, err error) {
 		//    | NULL |  52  |
 		//    | NULL |  52  |
 		if containsNull {
-			if !wantUnmatchedLeft {
-				scratch = encoding[:0]
-				// Failed to match -- no matching row, nothing to do.
-				continue
-			}
-			// We append an empty right row to the left row, adding the result
-			// to our buffer for the subsequent call to Next().
-			n.pred.prepareRow(n.output, lrow, n.emptyRight)
-			if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+			scratch = encoding[:0]
+			// Failed to match -- a NULL key never matches a bucket, so this is
+			// handled the same way as a probe row with no matching bucket.
+			if err := n.joiner.onMissMatch(params, lrow); err != nil {
 				return false, err
 			}
-			return n.buffer.Next(), nil
+			if n.buffer.Next() {
+				return true, nil
+			}
+			continue
 		}
 
 		b, ok := n.buckets.Fetch(encoding)
 		if !ok {
-			if !wantUnmatchedLeft {
-				scratch = encoding[:0]
-				continue
-			}
-			// Left or full outer join: unmatched rows are padded with NULLs.
-			// Given that we did not find a matching right row we append an
-			// empty right row to the left row, adding the result to our buffer
-			// for the subsequent call to Next().
-			n.pred.prepareRow(n.output, lrow, n.emptyRight)
-			if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+			scratch = encoding[:0]
+			if err := n.joiner.onMissMatch(params, lrow); err != nil {
 				return false, err
 			}
-			return n.buffer.Next(), nil
+			if n.buffer.Next() {
+				return true, nil
+			}
+			continue
 		}
 
-		// We iterate through all the rows in the bucket attempting to match the
-		// on condition, if the on condition passes we add it to the buffer.
-		foundMatch := false
-		for idx, rrow := range b.Rows() {
-			passesOnCond, err := n.pred.eval(&n.planner.evalCtx, n.output, lrow, rrow)
+		if n.asof != nil {
+			// ASOF JOIN has at most one match per probe row: the bucket row
+			// closest to lrow's asof value, found by binary search instead
+			// of a linear scan.
+			asofValAt := func(i int) (parser.Datum, error) {
+				row, err := n.buckets.Row(params.ctx, b, i)
+				if err != nil {
+					return nil, err
+				}
+				return row[n.asof.rightCol], nil
+			}
+			idx, err := probeAsofIndex(b.NumRows(), asofValAt, n.asof.op, lrow[n.asof.leftCol])
 			if err != nil {
 				return false, err
 			}
-
-			if !passesOnCond {
-				continue
+			if idx >= 0 {
+				rrow, err := n.buckets.Row(params.ctx, b, idx)
+				if err != nil {
+					return false, err
+				}
+				n.pred.prepareRow(n.output, lrow, rrow)
+				if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+					return false, err
+				}
+			} else if n.joinType == joinTypeAsofLeftOuter {
+				n.pred.prepareRow(n.output, lrow, n.emptyRight)
+				if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+					return false, err
+				}
+			}
+			if n.buffer.Next() {
+				return true, nil
 			}
-			foundMatch = true
+			scratch = encoding[:0]
+			continue
+		}
 
-			n.pred.prepareRow(n.output, lrow, rrow)
-			if wantUnmatchedRight {
-				// Mark the row as seen if we need to retrieve the rows
-				// without matches for right or full joins later.
-				b.MarkSeen(idx)
+		// We iterate through all the rows in the bucket, letting the joiner
+		// decide (based on the join type) whether and what to emit for each
+		// candidate pair.
+		foundMatch := false
+		for idx := 0; idx < b.NumRows(); idx++ {
+			rrow, err := n.buckets.Row(params.ctx, b, idx)
+			if err != nil {
+				return false, err
 			}
-			if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+			matched, err := n.joiner.tryToMatch(params, lrow, rrow, idx, b)
+			if err != nil {
 				return false, err
 			}
+			if matched {
+				foundMatch = true
+				if n.joinType == joinTypeLeftSemi {
+					// A semi join only needs a single witness per probe row;
+					// unlike right semi join, stopping early here doesn't
+					// skip any bookkeeping that later rows depend on.
+					break
+				}
+			}
 		}
-		if !foundMatch && wantUnmatchedLeft {
-			// If none of the rows matched the on condition and we are computing a
-			// left or full outer join, we need to add a row with an empty
-			// right side.
-			n.pred.prepareRow(n.output, lrow, n.emptyRight)
-			if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
+		if !foundMatch {
+			if err := n.joiner.onMissMatch(params, lrow); err != nil {
 				return false, err
 			}
 		}
@@ -514,19 +2005,8 @@ func (n *joinNode) Next(params runParams) (res bool, err error) {
 	if !wantUnmatchedRight {
 		return false, nil
 	}
-
-	for _, b := range n.buckets.Buckets() {
-		for idx, rrow := range b.Rows() {
-			if err := params.p.cancelChecker.Check(); err != nil {
-				return false, err
-			}
-			if !b.Seen(idx) {
-				n.pred.prepareRow(n.output, n.emptyLeft, rrow)
-				if _, err := n.buffer.AddRow(params.ctx, n.output); err != nil {
-					return false, err
-				}
-			}
-		}
+	if err := n.emitUnmatchedRightRows(params); err != nil {
+		return false, err
 	}
 	n.finishedOutput = true
 
@@ -535,11 +2015,30 @@ func (n *joinNode) Next(params runParams) (res bool, err error) {
 
 // Values implements the planNode interface.
 func (n *joinNode) Values() parser.Datums {
+	if n.parallel != nil {
+		// A worker could still be appending to n.buffer concurrently; take
+		// the same lock waitForParallelRow/beginParallelProbe use around
+		// every other access to it.
+		n.parallel.bufMu.Lock()
+		defer n.parallel.bufMu.Unlock()
+	}
 	return n.buffer.Values()
 }
 
 // Close implements the planNode interface.
 func (n *joinNode) Close(ctx context.Context) {
+	if n.parallel != nil {
+		// A parallel probe may still have worker/coordinator goroutines
+		// running (e.g. a LIMIT above this join stopped pulling before they
+		// finished, or the query errored out elsewhere). Tell them to give
+		// up and wait for them to actually exit before tearing down
+		// n.buffer and the child plans out from under them -- otherwise
+		// they'd go on calling AddRow on a nil buffer or Next/Values on a
+		// closed plan in the background.
+		close(n.parallel.stop)
+		<-n.parallel.done
+		n.parallel = nil
+	}
 	n.buffer.Close(ctx)
 	n.buffer = nil
 	n.buckets.Close(ctx)
@@ -553,6 +2052,17 @@ func (n *joinNode) joinOrdering() physicalProps {
 	if len(n.mergeJoinOrdering) == 0 {
 		return physicalProps{}
 	}
+
+	if n.joinType.isSemiOrAntiJoin() {
+		// Semi/anti joins only ever surface the outer side's columns, so
+		// there's no merged-column offset to account for: the ordering is
+		// just whatever the outer side already provides.
+		if n.joinType.isRightSemiOrAntiJoin() {
+			return planPhysicalProps(n.right.plan)
+		}
+		return planPhysicalProps(n.left.plan)
+	}
+
 	info := physicalProps{}
 
 	// n.Columns has the following schema on equality JOINs:
@@ -594,7 +2104,14 @@ func (n *joinNode) joinOrdering() physicalProps {
 	}
 
 	// TODO(arjun): Support order propagation for other JOIN types.
-	if n.joinType != joinTypeInner {
+	//
+	// joinTypeAsofInner is let through alongside joinTypeInner: like a plain
+	// inner join, it only emits matched pairs and never NULL-pads an
+	// unmatched row the way an outer join would. joinTypeAsofLeftOuter stays
+	// excluded for the same reason joinTypeLeftOuter is -- its unmatched
+	// left rows get a NULL-padded right side, which the addNotNullColumn
+	// calls below don't account for.
+	if n.joinType != joinTypeInner && n.joinType != joinTypeAsofInner {
 		return info
 	}
 
@@ -624,7 +2141,23 @@ func (n *joinNode) joinOrdering() physicalProps {
 		}
 	}
 
-	if leftOrd.isKey(leftEqSet) && rightOrd.isKey(rightEqSet) {
+	if n.hasAtMostOneMatchPerProbeRow() {
+		// ASOF JOIN matches at most one bucket (right-side) row per probe
+		// (left-side) row by construction -- the single closest one by the
+		// asof column -- so the left side's key sets survive the join even
+		// though the right side's equality prefix generally isn't a key on
+		// its own (many right rows can share the same equality prefix and
+		// just differ in asof column, which is exactly what the
+		// closest-match search is for).
+		for _, k := range leftOrd.weakKeys {
+			// Translate column indices.
+			var s util.FastIntSet
+			for c, ok := k.Next(0); ok; c, ok = k.Next(c + 1) {
+				s.Add(leftCol(c))
+			}
+			info.addWeakKey(s)
+		}
+	} else if leftOrd.isKey(leftEqSet) && rightOrd.isKey(rightEqSet) {
 		for _, k := range leftOrd.weakKeys {
 			// Translate column indices.
 			var s util.FastIntSet
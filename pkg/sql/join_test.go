@@ -0,0 +1,240 @@
+// Copyright 2016 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License.
+
+package sql
+
+import (
+	"testing"
+
+	"github.com/pkg/errors"
+
+	"github.com/cockroachdb/cockroach/pkg/sql/parser"
+)
+
+// asofRows fetches asof values out of a plain slice of ints, standing in for
+// the bucket.rowIdxs/buckets.Row indirection probeAsofIndex's callers use in
+// production; it lets these cases exercise the real search logic without a
+// buckets/rowContainer fixture.
+func asofRows(vals ...int) func(i int) (parser.Datum, error) {
+	return func(i int) (parser.Datum, error) {
+		return parser.NewDInt(parser.DInt(vals[i])), nil
+	}
+}
+
+func TestProbeAsofIndex(t *testing.T) {
+	testCases := []struct {
+		name     string
+		n        int
+		asofVals func(i int) (parser.Datum, error)
+		op       asofCompareOp
+		probeVal parser.Datum
+		want     int
+	}{
+		{
+			name:     "empty bucket",
+			n:        0,
+			asofVals: asofRows(),
+			op:       asofLE,
+			probeVal: parser.NewDInt(5),
+			want:     -1,
+		},
+		{
+			name:     "probeVal is NULL never matches, asofLE",
+			n:        3,
+			asofVals: asofRows(1, 2, 3),
+			op:       asofLE,
+			probeVal: parser.DNull,
+			want:     -1,
+		},
+		{
+			name:     "probeVal is NULL never matches, asofGE",
+			n:        3,
+			asofVals: asofRows(1, 2, 3),
+			op:       asofGE,
+			probeVal: parser.DNull,
+			want:     -1,
+		},
+		{
+			name:     "asofLE exact tie returns the tied row",
+			n:        3,
+			asofVals: asofRows(1, 5, 9),
+			op:       asofLE,
+			probeVal: parser.NewDInt(5),
+			want:     1,
+		},
+		{
+			name:     "asofLT skips past a tie to the next larger row",
+			n:        3,
+			asofVals: asofRows(1, 5, 9),
+			op:       asofLT,
+			probeVal: parser.NewDInt(5),
+			want:     2,
+		},
+		{
+			name:     "asofLT with no row past the tie returns -1",
+			n:        1,
+			asofVals: asofRows(5),
+			op:       asofLT,
+			probeVal: parser.NewDInt(5),
+			want:     -1,
+		},
+		{
+			name:     "asofGE exact tie returns the tied row",
+			n:        3,
+			asofVals: asofRows(1, 5, 9),
+			op:       asofGE,
+			probeVal: parser.NewDInt(5),
+			want:     1,
+		},
+		{
+			name:     "asofGT skips past a tie to the next smaller row",
+			n:        3,
+			asofVals: asofRows(1, 5, 9),
+			op:       asofGT,
+			probeVal: parser.NewDInt(5),
+			want:     0,
+		},
+		{
+			name:     "asofGT with no row past the tie returns -1",
+			n:        1,
+			asofVals: asofRows(5),
+			op:       asofGT,
+			probeVal: parser.NewDInt(5),
+			want:     -1,
+		},
+		{
+			name:     "asofLE with no row at or above probeVal returns -1",
+			n:        3,
+			asofVals: asofRows(1, 2, 3),
+			op:       asofLE,
+			probeVal: parser.NewDInt(10),
+			want:     -1,
+		},
+		{
+			name:     "asofGE with no row at or below probeVal returns -1",
+			n:        3,
+			asofVals: asofRows(1, 2, 3),
+			op:       asofGE,
+			probeVal: parser.NewDInt(0),
+			want:     -1,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := probeAsofIndex(tc.n, tc.asofVals, tc.op, tc.probeVal)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tc.want {
+				t.Errorf("probeAsofIndex() = %d, want %d", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestProbeAsofIndexPropagatesFetchError(t *testing.T) {
+	boom := errors.New("row fetch failed")
+	asofVals := func(i int) (parser.Datum, error) {
+		return nil, boom
+	}
+	if _, err := probeAsofIndex(3, asofVals, asofLE, parser.NewDInt(5)); err != boom {
+		t.Fatalf("probeAsofIndex() error = %v, want %v", err, boom)
+	}
+}
+
+// naajFixture builds the minimal joinNode a naajPartialMatch test needs: a
+// buckets instance carrying the right side's NULL/partial-key state, and a
+// predicate carrying only the equality-column indices encode operates on.
+func naajFixture(leftEqCols int, rightHasNull bool, rightRowCount int) *joinNode {
+	leftIndices := make([]int, leftEqCols)
+	rightIndices := make([]int, leftEqCols)
+	for i := range leftIndices {
+		leftIndices[i] = i
+		rightIndices[i] = i
+	}
+	return &joinNode{
+		pred: &joinPredicate{
+			leftEqualityIndices:  leftIndices,
+			rightEqualityIndices: rightIndices,
+		},
+		buckets: buckets{
+			rightHasNull:    rightHasNull,
+			rightRowCount:   rightRowCount,
+			partialKeyIndex: make(map[uint64]map[string]bool),
+		},
+	}
+}
+
+func TestNaajPartialMatch(t *testing.T) {
+	d := func(vs ...interface{}) parser.Datums {
+		row := make(parser.Datums, len(vs))
+		for i, v := range vs {
+			if v == nil {
+				row[i] = parser.DNull
+				continue
+			}
+			row[i] = parser.NewDInt(parser.DInt(v.(int)))
+		}
+		return row
+	}
+
+	t.Run("right side has a NULL, suppresses every probe", func(t *testing.T) {
+		n := naajFixture(1, true /* rightHasNull */, 1)
+		if !n.naajPartialMatch(d(1)) {
+			t.Error("naajPartialMatch() = false, want true (rule 2: right side has NULL)")
+		}
+	})
+
+	t.Run("probe with no NULL never suppressed by this rule", func(t *testing.T) {
+		n := naajFixture(1, false, 3)
+		if n.naajPartialMatch(d(1)) {
+			t.Error("naajPartialMatch() = true, want false (no NULL in probe)")
+		}
+	})
+
+	t.Run("probe NULL in every equality column, non-empty right side", func(t *testing.T) {
+		n := naajFixture(2, false, 5)
+		if !n.naajPartialMatch(d(nil, nil)) {
+			t.Error("naajPartialMatch() = false, want true (rule 3: all-NULL probe vs non-empty right side)")
+		}
+	})
+
+	t.Run("probe NULL in every equality column, empty right side", func(t *testing.T) {
+		n := naajFixture(2, false, 0)
+		if n.naajPartialMatch(d(nil, nil)) {
+			t.Error("naajPartialMatch() = true, want false (rightRowCount == 0 must not suppress)")
+		}
+	})
+
+	t.Run("probe NULL in only some columns, matching partial key present", func(t *testing.T) {
+		n := naajFixture(2, false, 1)
+		if err := n.buildNAAJPartialIndex(d(5, 9)); err != nil {
+			t.Fatalf("buildNAAJPartialIndex: %v", err)
+		}
+		if !n.naajPartialMatch(d(nil, 9)) {
+			t.Error("naajPartialMatch() = false, want true (partial key matches a right row)")
+		}
+	})
+
+	t.Run("probe NULL in only some columns, no matching partial key", func(t *testing.T) {
+		n := naajFixture(2, false, 1)
+		if err := n.buildNAAJPartialIndex(d(5, 9)); err != nil {
+			t.Fatalf("buildNAAJPartialIndex: %v", err)
+		}
+		if n.naajPartialMatch(d(nil, 1)) {
+			t.Error("naajPartialMatch() = true, want false (no right row shares this partial key)")
+		}
+	})
+}